@@ -0,0 +1,181 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Coord represents a single (x, y) coordinate point to be reverse-geocoded in a batch.
+type Coord struct {
+	X float64
+	Y float64
+}
+
+// batchTransport is shared across every BatchInitializer so concurrent lookups reuse
+// keep-alive connections instead of each paying a fresh TCP/TLS handshake.
+var batchTransport = &http.Transport{
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 100,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// BatchInitializer is a lazy batch reverse-geocoder, running CoordToDistrict over many
+// points concurrently.
+type BatchInitializer struct {
+	Points      []Coord
+	AuthKey     string
+	concurrency int
+	dedup       bool
+	roundTo     int
+}
+
+// CoordToDistrictBatch reverse-geocodes every point in @points. By default it runs one
+// request per point sequentially; chain Concurrency, Dedup, and RoundTo to tune it for
+// dataset-scale jobs.
+//
+// See https://developers.kakao.com/docs/latest/ko/local/dev-guide#coord-to-district for more details.
+func CoordToDistrictBatch(points []Coord) *BatchInitializer {
+	return &BatchInitializer{
+		Points:      points,
+		AuthKey:     "KakaoAK ",
+		concurrency: 1,
+	}
+}
+
+// AuthorizeWith sets the authorization key to @key.
+func (bi *BatchInitializer) AuthorizeWith(key string) *BatchInitializer {
+	bi.AuthKey = "KakaoAK " + strings.TrimSpace(key)
+	return bi
+}
+
+// Concurrency sets the number of worker goroutines Collect uses to @n.
+func (bi *BatchInitializer) Concurrency(n int) *BatchInitializer {
+	bi.concurrency = n
+	return bi
+}
+
+// Dedup collapses points that share the same (x, y) - after RoundTo snapping, if set -
+// into a single upstream call, fanning its result back out to every matching point.
+func (bi *BatchInitializer) Dedup(dedup bool) *BatchInitializer {
+	bi.dedup = dedup
+	return bi
+}
+
+// RoundTo snaps every point to a grid of @decimals decimal places before lookup, so that
+// near-duplicate points can share a single result under Dedup. A @decimals of 0 or less
+// leaves points unsnapped.
+func (bi *BatchInitializer) RoundTo(decimals int) *BatchInitializer {
+	bi.roundTo = decimals
+	return bi
+}
+
+// batchUnit is one upstream call to make, and the indices of bi.Points its result applies to.
+type batchUnit struct {
+	x, y    float64
+	indices []int
+}
+
+// Collect runs CoordToDistrict over bi.Points through a worker pool bounded by
+// Concurrency, respecting @ctx cancellation, and returns results in input order. The
+// returned []error is parallel to bi.Points; a nil entry means that point succeeded.
+func (bi *BatchInitializer) Collect(ctx context.Context) ([]CoordToDistrictResult, []error) {
+	results := make([]CoordToDistrictResult, len(bi.Points))
+	errs := make([]error, len(bi.Points))
+
+	snapped := make([][2]float64, len(bi.Points))
+	for i, p := range bi.Points {
+		x, y := p.X, p.Y
+		if bi.roundTo > 0 {
+			x, y = round(x, bi.roundTo), round(y, bi.roundTo)
+		}
+		snapped[i] = [2]float64{x, y}
+	}
+
+	var units []batchUnit
+	if bi.dedup {
+		groups := make(map[[2]float64][]int)
+		var order [][2]float64
+		for i, p := range snapped {
+			if _, ok := groups[p]; !ok {
+				order = append(order, p)
+			}
+			groups[p] = append(groups[p], i)
+		}
+		for _, p := range order {
+			units = append(units, batchUnit{x: p[0], y: p[1], indices: groups[p]})
+		}
+	} else {
+		for i, p := range snapped {
+			units = append(units, batchUnit{x: p[0], y: p[1], indices: []int{i}})
+		}
+	}
+
+	concurrency := bi.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	client := &http.Client{Transport: batchTransport}
+	jobs := make(chan batchUnit, len(units))
+	for _, u := range units {
+		jobs <- u
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range jobs {
+				var res CoordToDistrictResult
+				err := ctx.Err()
+				if err == nil {
+					res, err = coordToDistrictWith(ctx, client, u.x, u.y, bi.AuthKey)
+				}
+				for _, i := range u.indices {
+					results[i] = res
+					errs[i] = err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results, errs
+}
+
+// round snaps @v to @decimals decimal places.
+func round(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v*p) / p
+}
+
+// coordToDistrictWith performs a single coord2regioncode lookup against @client, building
+// the same request CoordToDistrictInitializer.Collect does for one-off calls, fixed to JSON
+// format and WGS84 input and output coordinates.
+func coordToDistrictWith(ctx context.Context, client *http.Client, x, y float64, authKey string) (res CoordToDistrictResult, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%sgeo/coord2regioncode.json?x=%s&y=%s&input_coord=WGS84&output_coord=WGS84",
+			prefix, strconv.FormatFloat(x, 'f', -1, 64), strconv.FormatFloat(y, 'f', -1, 64)), nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Authorization", authKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	return
+}
@@ -1,7 +1,6 @@
 package pose
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"internal/common"
@@ -84,40 +83,42 @@ func (ai *AnalyzeVideoInitializer) ReceiveTo(url string) *AnalyzeVideoInitialize
 // Collect returns the result of AnalyzeVideo.
 func (ai *AnalyzeVideoInitializer) Collect() (res AnalyzeVideoResult, err error) {
 	client := new(http.Client)
-	body := new(bytes.Buffer)
-	writer := multipart.NewWriter(body)
-	if err != nil {
-		return
-	}
 
+	var req *http.Request
 	if ai.File != nil {
-		part, err := writer.CreateFormFile("file", filepath.Base(ai.File.Name()))
+		pr, pw := io.Pipe()
+		writer := multipart.NewWriter(pw)
+
+		go func() {
+			part, ferr := writer.CreateFormFile("file", filepath.Base(ai.File.Name()))
+			if ferr != nil {
+				pw.CloseWithError(ferr)
+				return
+			}
+			if _, ferr := io.Copy(part, ai.File); ferr != nil {
+				pw.CloseWithError(ferr)
+				return
+			}
+			pw.CloseWithError(writer.Close())
+		}()
+
+		req, err = http.NewRequest(http.MethodPost, fmt.Sprintf("%s/job?file=%s", prefix, ai.File.Name()), pr)
 		if err != nil {
-			return res, err
+			return
 		}
-		io.Copy(part, ai.File)
-	}
-	defer writer.Close()
-
-	var req *http.Request
-	if ai.File != nil {
-		req, err = http.NewRequest(http.MethodPost, fmt.Sprintf("%s/job?file=%s", prefix, ai.File.Name()), body)
+		req.ContentLength = -1
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		defer ai.File.Close()
 	} else {
 		req, err = http.NewRequest(http.MethodPost, fmt.Sprintf("%s/job?video_url=%s", prefix, ai.VideoURL), nil)
-	}
-
-	if err != nil {
-		return
+		if err != nil {
+			return
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
 
 	req.Close = true
-
 	req.Header.Set(common.Authorization, ai.AuthKey)
-	if ai.File != nil {
-		req.Header.Set("Content-Type", "multipart/form-data")
-	} else {
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -0,0 +1,250 @@
+package pose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"internal/common"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// JobStatus represents the current state of a submitted AnalyzeVideo job.
+type JobStatus struct {
+	JobId    string `json:"job_id"`
+	Status   string `json:"status"`
+	Progress int    `json:"progress"`
+}
+
+// String implements fmt.Stringer.
+func (js JobStatus) String() string { return common.String(js) }
+
+// Done reports whether the job has reached a terminal state.
+func (js JobStatus) Done() bool {
+	return js.Status == "success" || js.Status == "failed"
+}
+
+// CheckJobInitializer is a lazy job-status checker.
+type CheckJobInitializer struct {
+	AuthKey string
+	JobId   string
+}
+
+// CheckVideoJob checks the progress of the AnalyzeVideo job identified by @jobId.
+//
+// For more details visit https://developers.kakao.com/docs/latest/en/pose/dev-guide#job-check.
+func CheckVideoJob(jobId string) *CheckJobInitializer {
+	return &CheckJobInitializer{
+		AuthKey: common.KeyPrefix,
+		JobId:   jobId,
+	}
+}
+
+// AuthorizeWith sets the authorization key to @key.
+func (cj *CheckJobInitializer) AuthorizeWith(key string) *CheckJobInitializer {
+	cj.AuthKey = common.FormatKey(key)
+	return cj
+}
+
+// Collect returns the current status of the job.
+func (cj *CheckJobInitializer) Collect() (res JobStatus, err error) {
+	client := new(http.Client)
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/job/%s", prefix, cj.JobId), nil)
+	if err != nil {
+		return
+	}
+	req.Close = true
+	req.Header.Set(common.Authorization, cj.AuthKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return
+	}
+	return
+}
+
+// WaitForCompletion polls the job status at increasing intervals, starting at @interval and
+// doubling up to a 30-second ceiling after every attempt, until the job reaches a terminal
+// state or @ctx is cancelled.
+func (cj *CheckJobInitializer) WaitForCompletion(ctx context.Context, interval time.Duration) (JobStatus, error) {
+	const maxInterval = 30 * time.Second
+
+	for {
+		status, err := cj.Collect()
+		if err != nil {
+			return status, err
+		}
+		if status.Done() {
+			return status, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		if interval *= 2; interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+// Person represents the key points detected for a single person in a video frame.
+type Person struct {
+	Keypoints [][]float64 `json:"keypoints"`
+}
+
+// Frame represents every person detected in a single analyzed video frame.
+type Frame struct {
+	Persons []Person `json:"persons"`
+}
+
+// VideoAnalysisResult represents the fully decoded, frame-by-frame output of an
+// AnalyzeVideo job.
+type VideoAnalysisResult struct {
+	JobId  string  `json:"job_id"`
+	Frames []Frame `json:"frames"`
+}
+
+// String implements fmt.Stringer.
+func (vr VideoAnalysisResult) String() string { return common.String(vr) }
+
+// SaveAs saves vr to @filename.
+//
+// The file extension must be .json.
+func (vr VideoAnalysisResult) SaveAs(filename string) error {
+	return common.SaveAsJSON(vr, filename)
+}
+
+// GetVideoAnalysisResultInitializer is a lazy analysis result fetcher.
+type GetVideoAnalysisResultInitializer struct {
+	AuthKey string
+	JobId   string
+}
+
+// GetVideoAnalysisResult retrieves the decoded keypoint results of a completed
+// AnalyzeVideo job identified by @jobId.
+//
+// For more details visit https://developers.kakao.com/docs/latest/en/pose/dev-guide#job-result.
+func GetVideoAnalysisResult(jobId string) *GetVideoAnalysisResultInitializer {
+	return &GetVideoAnalysisResultInitializer{
+		AuthKey: common.KeyPrefix,
+		JobId:   jobId,
+	}
+}
+
+// AuthorizeWith sets the authorization key to @key.
+func (gr *GetVideoAnalysisResultInitializer) AuthorizeWith(key string) *GetVideoAnalysisResultInitializer {
+	gr.AuthKey = common.FormatKey(key)
+	return gr
+}
+
+// Collect returns the decoded video analysis result.
+func (gr *GetVideoAnalysisResultInitializer) Collect() (res VideoAnalysisResult, err error) {
+	client := new(http.Client)
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/job/%s/result", prefix, gr.JobId), nil)
+	if err != nil {
+		return
+	}
+	req.Close = true
+	req.Header.Set(common.Authorization, gr.AuthKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return
+	}
+	return
+}
+
+// CallbackServer receives the VideoAnalysisResult callbacks the Kakao API posts to the
+// CallbackURL registered via AnalyzeVideoInitializer.ReceiveTo, without the caller having
+// to write the HTTP plumbing themselves.
+type CallbackServer struct {
+	server *http.Server
+	done   chan struct{}
+
+	mu      sync.Mutex
+	jobIds  map[string]struct{}
+	results chan VideoAnalysisResult
+}
+
+// callbackResultBuffer lets a burst of callbacks land before any goroutine is ranging
+// over Results, without blocking the handler goroutine-per-request.
+const callbackResultBuffer = 64
+
+// NewCallbackServer creates a CallbackServer listening on @addr.
+func NewCallbackServer(addr string) *CallbackServer {
+	cs := &CallbackServer{
+		done:    make(chan struct{}),
+		jobIds:  make(map[string]struct{}),
+		results: make(chan VideoAnalysisResult, callbackResultBuffer),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", cs.handle)
+	cs.server = &http.Server{Addr: addr, Handler: mux}
+	return cs
+}
+
+// Register marks @jobId as expected, so that callbacks for unregistered jobs are rejected.
+func (cs *CallbackServer) Register(jobId string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.jobIds[jobId] = struct{}{}
+}
+
+// Results returns the channel VideoAnalysisResults are delivered on as callbacks arrive.
+func (cs *CallbackServer) Results() <-chan VideoAnalysisResult {
+	return cs.results
+}
+
+// ListenAndServe starts the callback server. It blocks until the server is shut down, at
+// which point it returns http.ErrServerClosed.
+func (cs *CallbackServer) ListenAndServe() error {
+	return cs.server.ListenAndServe()
+}
+
+// Shutdown gracefully stops the callback server.
+func (cs *CallbackServer) Shutdown(ctx context.Context) error {
+	close(cs.done)
+	return cs.server.Shutdown(ctx)
+}
+
+func (cs *CallbackServer) handle(w http.ResponseWriter, r *http.Request) {
+	var res VideoAnalysisResult
+	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cs.mu.Lock()
+	_, known := cs.jobIds[res.JobId]
+	cs.mu.Unlock()
+	if !known {
+		http.Error(w, errors.New("pose: unrecognized job_id").Error(), http.StatusForbidden)
+		return
+	}
+
+	// results is buffered so a burst of callbacks doesn't block. Once the buffer is full the
+	// send waits for a receiver; the select ends that wait with a 503 when the server shuts
+	// down, or without a reply when the request is cancelled.
+	select {
+	case cs.results <- res:
+		w.WriteHeader(http.StatusOK)
+	case <-cs.done:
+		http.Error(w, "pose: callback server is shutting down", http.StatusServiceUnavailable)
+	case <-r.Context().Done():
+	}
+}
@@ -0,0 +1,176 @@
+package vision
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheStats reports hit/miss counters for a Cache.
+type CacheStats struct {
+	Hits   int
+	Misses int
+}
+
+// Cache is a content-addressable, on-disk cache for Vision API responses, keyed by a
+// digest of the source image (or ImageUrl, when no local file is given) plus the request
+// parameters. It is shared across every initializer pointed at the same directory, so
+// concurrent requests for the same key coalesce into a single upstream call.
+type Cache struct {
+	dir string
+	ttl time.Duration
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+	stats CacheStats
+}
+
+var (
+	cacheRegistryMu sync.Mutex
+	cacheRegistry   = map[string]*Cache{}
+)
+
+// WithCache returns the shared Cache rooted at @dir, creating it the first time @dir is
+// seen. Entries older than @ttl are treated as misses; a zero ttl never expires entries.
+// The ttl is fixed by that first call: later calls for the same @dir return the existing
+// Cache and their @ttl is ignored.
+func WithCache(dir string, ttl time.Duration) *Cache {
+	cacheRegistryMu.Lock()
+	defer cacheRegistryMu.Unlock()
+
+	if c, ok := cacheRegistry[dir]; ok {
+		return c
+	}
+	c := &Cache{dir: dir, ttl: ttl, locks: make(map[string]*sync.Mutex)}
+	cacheRegistry[dir] = c
+	return c
+}
+
+// cacheEntry is the on-disk representation of a cached result.
+type cacheEntry struct {
+	StoredAt int64                 `json:"stored_at"`
+	Result   ThumbnailDetectResult `json:"result"`
+}
+
+// keyFor derives the cache key for a request against @ti, hashing the source image bytes
+// (or ImageUrl, when no local file is set) together with the request parameters.
+func (c *Cache) keyFor(ti *ThumbnailDetectInitializer) (string, error) {
+	h := sha256.New()
+	if ti.Image != nil {
+		if _, err := ti.Image.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(h, ti.Image); err != nil {
+			return "", err
+		}
+		if _, err := ti.Image.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+	} else {
+		io.WriteString(h, ti.ImageUrl)
+	}
+	fmt.Fprintf(h, "|thumbnail/detect|%d|%d", ti.Width, ti.Height)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// lockFor returns the per-key mutex used to coalesce concurrent requests for the same key.
+func (c *Cache) lockFor(key string) *sync.Mutex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.locks[key]
+	if !ok {
+		l = new(sync.Mutex)
+		c.locks[key] = l
+	}
+	return l
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// get returns the cached result for @key, if present and not expired.
+func (c *Cache) get(key string) (ThumbnailDetectResult, bool) {
+	var entry cacheEntry
+	bs, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return entry.Result, false
+	}
+	if err := json.Unmarshal(bs, &entry); err != nil {
+		return entry.Result, false
+	}
+	if c.ttl > 0 && time.Since(time.Unix(entry.StoredAt, 0)) > c.ttl {
+		return entry.Result, false
+	}
+	return entry.Result, true
+}
+
+// put atomically (tmp file + rename) writes @res to disk under @key.
+func (c *Cache) put(key string, res ThumbnailDetectResult) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	bs, err := json.Marshal(cacheEntry{StoredAt: time.Now().Unix(), Result: res})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, "tmp-*.json")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(bs); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return os.Rename(tmpName, c.path(key))
+}
+
+// ClearCache removes every cached entry under c's directory.
+func (c *Cache) ClearCache() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		if filepath.Ext(e.Name()) == ".json" {
+			if err := os.Remove(filepath.Join(c.dir, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Stats returns c's current hit/miss counters.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+func (c *Cache) recordHit() {
+	c.mu.Lock()
+	c.stats.Hits++
+	c.mu.Unlock()
+}
+
+func (c *Cache) recordMiss() {
+	c.mu.Lock()
+	c.stats.Misses++
+	c.mu.Unlock()
+}
@@ -2,14 +2,23 @@ package vision
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
 	"internal/common"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/buckket/go-blurhash"
+	xdraw "golang.org/x/image/draw"
 )
 
 // Thumbnail represents coordinates of the point starting the thumbnail image and its width, height
@@ -31,6 +40,10 @@ type ThumbnailResult struct {
 type ThumbnailDetectResult struct {
 	Rid    string          `json:"rid"`
 	Result ThumbnailResult `json:"result"`
+
+	// BlurHash is a compact perceptual placeholder for the detected Thumbnail rectangle,
+	// populated only when WithBlurHash was set on the initializer that produced this result.
+	BlurHash string `json:"blur_hash,omitempty"`
 }
 
 // String implements fmt.Stringer.
@@ -50,6 +63,12 @@ type ThumbnailDetectInitializer struct {
 	ImageUrl string
 	Width    int
 	Height   int
+
+	blurHash      bool
+	blurHashXComp int
+	blurHashYComp int
+
+	cache *Cache
 }
 
 // ThumbnailDetect helps to create a thumbnail image by detecting the representative area out of the given @source.
@@ -83,35 +102,85 @@ func (ti *ThumbnailDetectInitializer) HeightTo(ratio int) *ThumbnailDetectInitia
 	return ti
 }
 
+// WithBlurHash opts ti into computing a BlurHash placeholder for the detected Thumbnail
+// rectangle, using @xComp x @yComp components (the BlurHash algorithm recommends 4x3 for
+// typical photos). The crop and encode happen locally, after the Kakao API responds, and
+// the result is attached to ThumbnailDetectResult.BlurHash.
+func (ti *ThumbnailDetectInitializer) WithBlurHash(xComp, yComp int) *ThumbnailDetectInitializer {
+	ti.blurHash = true
+	ti.blurHashXComp = xComp
+	ti.blurHashYComp = yComp
+	return ti
+}
+
+// WithCache opts ti into checking @cache (see WithCache) before calling the Kakao
+// endpoint, and into populating it on a miss.
+func (ti *ThumbnailDetectInitializer) WithCache(cache *Cache) *ThumbnailDetectInitializer {
+	ti.cache = cache
+	return ti
+}
+
 // Collect returns the thumbnail detection result.
 func (ti *ThumbnailDetectInitializer) Collect() (res ThumbnailDetectResult, err error) {
-	client := new(http.Client)
-	body := new(bytes.Buffer)
-	writer := multipart.NewWriter(body)
+	if ti.cache != nil {
+		key, kerr := ti.cache.keyFor(ti)
+		if kerr != nil {
+			return res, kerr
+		}
+		lock := ti.cache.lockFor(key)
+		lock.Lock()
+		defer lock.Unlock()
 
-	if ti.Image != nil {
-		part, err := writer.CreateFormFile("image", filepath.Base(ti.Image.Name()))
-		if err != nil {
-			return res, err
+		if cached, ok := ti.cache.get(key); ok {
+			ti.cache.recordHit()
+			return cached, nil
 		}
-		io.Copy(part, ti.Image)
+		ti.cache.recordMiss()
+		defer func() {
+			if err == nil {
+				err = ti.cache.put(key, res)
+			}
+		}()
 	}
-	defer writer.Close()
 
-	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/thumbnail/detect?image_url=%s&width=%d&height=%d",
-		prefix, ti.ImageUrl, ti.Width, ti.Height), body)
-	if err != nil {
-		return res, err
-	}
-	req.Close = true
-	req.Header.Set(common.Authorization, ti.AuthKey)
+	client := new(http.Client)
 
+	var req *http.Request
 	if ti.Image != nil {
+		pr, pw := io.Pipe()
+		writer := multipart.NewWriter(pw)
+
+		go func() {
+			part, ferr := writer.CreateFormFile("image", filepath.Base(ti.Image.Name()))
+			if ferr != nil {
+				pw.CloseWithError(ferr)
+				return
+			}
+			if _, ferr := io.Copy(part, ti.Image); ferr != nil {
+				pw.CloseWithError(ferr)
+				return
+			}
+			pw.CloseWithError(writer.Close())
+		}()
+
+		req, err = http.NewRequest(http.MethodPost, fmt.Sprintf("%s/thumbnail/detect?image_url=%s&width=%d&height=%d",
+			prefix, ti.ImageUrl, ti.Width, ti.Height), pr)
+		if err != nil {
+			return res, err
+		}
+		req.ContentLength = -1
 		req.Header.Set("Content-Type", writer.FormDataContentType())
+		defer ti.Image.Close()
 	} else {
+		req, err = http.NewRequest(http.MethodPost, fmt.Sprintf("%s/thumbnail/detect?image_url=%s&width=%d&height=%d",
+			prefix, ti.ImageUrl, ti.Width, ti.Height), nil)
+		if err != nil {
+			return res, err
+		}
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
-	defer ti.Image.Close()
+	req.Close = true
+	req.Header.Set(common.Authorization, ti.AuthKey)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -123,5 +192,261 @@ func (ti *ThumbnailDetectInitializer) Collect() (res ThumbnailDetectResult, err
 	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
 		return res, err
 	}
+
+	if ti.blurHash {
+		cropped, cerr := ti.cropThumbnail(res)
+		if cerr != nil {
+			return res, cerr
+		}
+		xComp, yComp := ti.blurHashXComp, ti.blurHashYComp
+		if xComp == 0 {
+			xComp = 4
+		}
+		if yComp == 0 {
+			yComp = 3
+		}
+		hash, cerr := blurhash.Encode(xComp, yComp, cropped)
+		if cerr != nil {
+			return res, cerr
+		}
+		res.BlurHash = hash
+	}
 	return
 }
+
+// CropOptions controls how CollectAndCrop encodes the cropped thumbnail.
+type CropOptions struct {
+	// Quality is the JPEG encoding quality (1-100, default 90). Ignored for PNG output.
+	Quality int
+	// Scaler resamples the crop to Width x Height of the initializer when both are set
+	// and the crop's size differs from them. Defaults to xdraw.CatmullRom.
+	Scaler xdraw.Scaler
+}
+
+// CollectAndCrop calls Collect and, using the returned Thumbnail rectangle, re-reads the
+// source image (the local Image file, or ImageUrl when no file was given), crops it, and
+// writes the result to @outPath as @format ("jpeg" or "png"). opts may be nil to use
+// the defaults.
+//
+// The Kakao API only reports the thumbnail coordinates, so producing the actual image is
+// left to the caller; this saves every downstream user from reimplementing the same crop.
+func (ti *ThumbnailDetectInitializer) CollectAndCrop(outPath, format string, opts *CropOptions) (ThumbnailDetectResult, error) {
+	res, err := ti.Collect()
+	if err != nil {
+		return res, err
+	}
+	return res, ti.cropAndWrite(res, outPath, format, opts)
+}
+
+// cropAndWrite does the crop/resize/orient/encode work behind CollectAndCrop, taking an
+// already-fetched ThumbnailDetectResult so it can be exercised directly in tests without a
+// network round-trip.
+func (ti *ThumbnailDetectInitializer) cropAndWrite(res ThumbnailDetectResult, outPath, format string, opts *CropOptions) error {
+	cropped, orientation, err := ti.crop(res)
+	if err != nil {
+		return err
+	}
+
+	if opts == nil {
+		opts = &CropOptions{}
+	}
+	quality := opts.Quality
+	if quality == 0 {
+		quality = 90
+	}
+	scaler := opts.Scaler
+	if scaler == nil {
+		scaler = xdraw.CatmullRom
+	}
+
+	// ti.Width/ti.Height are the dimensions requested from the Kakao API, so they're in
+	// the same unrotated coordinate space as cropped; resize here, before orientation is
+	// applied below, so a 90-degree rotation doesn't compare/resample against transposed
+	// target dimensions.
+	resizedOrCropped := cropped
+	if ti.Width > 0 && ti.Height > 0 && (cropped.Bounds().Dx() != ti.Width || cropped.Bounds().Dy() != ti.Height) {
+		resized := image.NewRGBA(image.Rect(0, 0, ti.Width, ti.Height))
+		scaler.Scale(resized, resized.Bounds(), cropped, cropped.Bounds(), xdraw.Over, nil)
+		resizedOrCropped = resized
+	}
+	final := applyExifOrientation(resizedOrCropped, orientation)
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	switch strings.ToLower(format) {
+	case "jpeg", "jpg":
+		return jpeg.Encode(out, final, &jpeg.Options{Quality: quality})
+	case "png":
+		return png.Encode(out, final)
+	default:
+		return fmt.Errorf("vision: unsupported crop format %q", format)
+	}
+}
+
+// maxRemoteImageBytes caps how much of a remote ImageUrl decodeSource will read, so that
+// cropping or blurhashing never pulls an unbounded response into memory.
+const maxRemoteImageBytes = 20 * 1024 * 1024
+
+// cropThumbnail re-reads the source image and crops it to res.Result.Thumbnail. The
+// Thumbnail rectangle Kakao returns is computed against the image as it decoded it, i.e.
+// before any EXIF rotation is applied, so the crop happens first and the EXIF orientation
+// is corrected afterwards on the (now much smaller) cropped buffer.
+func (ti *ThumbnailDetectInitializer) cropThumbnail(res ThumbnailDetectResult) (*image.RGBA, error) {
+	cropped, orientation, err := ti.crop(res)
+	if err != nil {
+		return nil, err
+	}
+	return applyExifOrientation(cropped, orientation), nil
+}
+
+// crop re-reads the source image and crops it to res.Result.Thumbnail, returning the crop
+// as Kakao itself decoded the source - i.e. not yet EXIF-rotated - alongside the raw EXIF
+// orientation value. Kakao's Width/Height (and so the Thumbnail rectangle) are reported
+// against that unrotated image, so any further sizing against ti.Width/ti.Height must
+// happen in this same, unrotated coordinate space before the orientation is applied.
+func (ti *ThumbnailDetectInitializer) crop(res ThumbnailDetectResult) (*image.RGBA, int, error) {
+	src, orientation, err := ti.decodeSource()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	t := res.Result.Thumbnail
+	rect := image.Rect(t.X, t.Y, t.X+t.Width, t.Y+t.Height).Intersect(src.Bounds())
+	cropped := image.NewRGBA(rect.Sub(rect.Min))
+	draw.Draw(cropped, cropped.Bounds(), src, rect.Min, draw.Src)
+	return cropped, orientation, nil
+}
+
+// decodeSource reopens ti.Image, or fetches ti.ImageUrl when no local file was given, and
+// decodes it as JPEG or PNG. It returns the image as Kakao itself decoded it - i.e. not
+// yet EXIF-rotated - alongside the raw EXIF orientation value, so callers can apply the
+// rotation at the right point relative to any coordinates reported against the unrotated
+// image (see cropThumbnail).
+func (ti *ThumbnailDetectInitializer) decodeSource() (image.Image, int, error) {
+	var r io.Reader
+	if ti.Image != nil {
+		// Reopen by path rather than seeking ti.Image: Collect may have already closed
+		// that handle by the time a crop runs.
+		f, err := os.Open(ti.Image.Name())
+		if err != nil {
+			return nil, 0, err
+		}
+		defer f.Close()
+		r = f
+	} else {
+		resp, err := http.Get(ti.ImageUrl)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer resp.Body.Close()
+		r = io.LimitReader(resp.Body, maxRemoteImageBytes)
+	}
+
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return img, exifOrientation(raw), nil
+}
+
+// exifOrientation scans a JPEG's APP1 segment for the EXIF orientation tag (0x0112) and
+// returns its value, or 0 (no-op) if the image has no EXIF data or isn't a JPEG.
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 0
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 0
+		}
+		marker := data[i+1]
+		if marker == 0xD8 || marker == 0xD9 {
+			break
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if marker == 0xE1 && i+4+6 <= len(data) && string(data[i+4:i+4+6]) == "Exif\x00\x00" {
+			tiff := data[i+4+6:]
+			return parseTiffOrientation(tiff)
+		}
+		i += 2 + size
+	}
+	return 0
+}
+
+// parseTiffOrientation walks a TIFF header's 0th IFD looking for tag 0x0112.
+func parseTiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifdOffset := order.Uint32(tiff[4:8])
+	if int(ifdOffset)+2 > len(tiff) {
+		return 0
+	}
+	count := order.Uint16(tiff[ifdOffset : ifdOffset+2])
+	for e := 0; e < int(count); e++ {
+		entry := tiff[int(ifdOffset)+2+e*12:]
+		if len(entry) < 12 {
+			break
+		}
+		if order.Uint16(entry[0:2]) == 0x0112 {
+			return int(order.Uint16(entry[8:10]))
+		}
+	}
+	return 0
+}
+
+// applyExifOrientation rotates img to undo EXIF orientation values 3, 6, and 8 - the
+// three cases that are a pure rotation - which covers the vast majority of photos shot on
+// phones and cameras. The mirrored orientations (2, 4, 5, 7), which additionally require a
+// horizontal/vertical flip, are not handled and fall through to the no-op default, as does
+// a value of 0 or 1.
+func applyExifOrientation(img *image.RGBA, orientation int) *image.RGBA {
+	b := img.Bounds()
+	switch orientation {
+	case 3: // 180 degrees
+		dst := image.NewRGBA(b)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				dst.Set(b.Max.X-1-x+b.Min.X, b.Max.Y-1-y+b.Min.Y, img.At(x, y))
+			}
+		}
+		return dst
+	case 6: // rotated 90 degrees CW
+		dst := image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				dst.Set(b.Max.Y-1-y, x, img.At(x, y))
+			}
+		}
+		return dst
+	case 8: // rotated 90 degrees CCW
+		dst := image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				dst.Set(y, b.Max.X-1-x, img.At(x, y))
+			}
+		}
+		return dst
+	default:
+		return img
+	}
+}
@@ -0,0 +1,116 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFixturePNG(t *testing.T, path string, w, h int) {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 0, A: 255})
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create fixture: %v", err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("encode fixture: %v", err)
+	}
+}
+
+// TestCollectAndCropAfterImageClosed guards against a regression where Collect closes
+// ti.Image once the request completes, leaving the crop step to read from an
+// already-closed handle. decodeSource must reopen the file by path instead.
+func TestCollectAndCropAfterImageClosed(t *testing.T) {
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "source.png")
+	writeFixturePNG(t, fixture, 20, 20)
+
+	src, err := os.Open(fixture)
+	if err != nil {
+		t.Fatalf("open fixture: %v", err)
+	}
+	// Simulate what Collect does once the HTTP round-trip finishes.
+	if err := src.Close(); err != nil {
+		t.Fatalf("close fixture: %v", err)
+	}
+
+	ti := &ThumbnailDetectInitializer{Image: src, Width: 10, Height: 10}
+	res := ThumbnailDetectResult{
+		Result: ThumbnailResult{
+			Thumbnail: Thumbnail{X: 2, Y: 2, Width: 10, Height: 10},
+		},
+	}
+
+	outPath := filepath.Join(dir, "out.png")
+	if err := ti.cropAndWrite(res, outPath, "png", nil); err != nil {
+		t.Fatalf("cropAndWrite: %v", err)
+	}
+
+	out, err := os.Open(outPath)
+	if err != nil {
+		t.Fatalf("open output: %v", err)
+	}
+	defer out.Close()
+
+	img, _, err := image.Decode(out)
+	if err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	if got := img.Bounds(); got.Dx() != 10 || got.Dy() != 10 {
+		t.Fatalf("output size = %v, want 10x10", got)
+	}
+}
+
+// TestCollectAndCropResizesInUnrotatedSpace checks that a crop whose size differs from
+// ti.Width/ti.Height is resampled to exactly those dimensions. The PNG fixture carries no
+// EXIF orientation, so no rotation is applied and the unrotated space is the output space.
+func TestCollectAndCropResizesInUnrotatedSpace(t *testing.T) {
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "source.png")
+	writeFixturePNG(t, fixture, 30, 20)
+
+	src, err := os.Open(fixture)
+	if err != nil {
+		t.Fatalf("open fixture: %v", err)
+	}
+	defer src.Close()
+
+	ti := &ThumbnailDetectInitializer{Image: src, Width: 10, Height: 20}
+	res := ThumbnailDetectResult{
+		Result: ThumbnailResult{
+			Thumbnail: Thumbnail{X: 0, Y: 0, Width: 20, Height: 20},
+		},
+	}
+
+	outPath := filepath.Join(dir, "out.png")
+	if err := ti.cropAndWrite(res, outPath, "png", nil); err != nil {
+		t.Fatalf("cropAndWrite: %v", err)
+	}
+
+	out, err := os.Open(outPath)
+	if err != nil {
+		t.Fatalf("open output: %v", err)
+	}
+	defer out.Close()
+
+	img, _, err := image.Decode(out)
+	if err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	if got := img.Bounds(); got.Dx() != ti.Width || got.Dy() != ti.Height {
+		t.Fatalf("output size = %v, want %dx%d", got, ti.Width, ti.Height)
+	}
+}